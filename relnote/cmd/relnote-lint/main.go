@@ -0,0 +1,86 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// The relnote-lint command lints a tree of release-note fragments,
+// reporting structured diagnostics in human-readable or JSON form.
+//
+// Usage:
+//
+//	relnote-lint [-json] [-stale-todo-days N] dir
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/build/relnote"
+)
+
+var (
+	jsonOut       = flag.Bool("json", false, "emit diagnostics as JSON")
+	staleTODODays = flag.Int("stale-todo-days", 0, "flag TODO-only fragments whose TODO line is older than this many days (0 disables the check)")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: relnote-lint [-json] [-stale-todo-days N] dir\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := flag.Arg(0)
+
+	opts := relnote.CheckTreeOptions{}
+	if *staleTODODays > 0 {
+		opts.StaleTODOAfter = time.Duration(*staleTODODays) * 24 * time.Hour
+		opts.BlameAge = gitBlameAge(dir)
+	}
+
+	errs := relnote.CheckTree(os.DirFS(dir), opts)
+	if *jsonOut {
+		if err := json.NewEncoder(os.Stdout).Encode(errs); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	} else {
+		for _, e := range errs {
+			fmt.Printf("%s:%d:%d: %s: %s\n", e.Path, e.Pos.StartLine, e.Pos.EndLine, e.Rule, e.Msg)
+		}
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+}
+
+// gitBlameAge returns a relnote.CheckTreeOptions.BlameAge function that
+// uses "git blame" in dir to find how long ago a line was last changed.
+func gitBlameAge(dir string) func(path string, line int) (time.Duration, bool) {
+	return func(path string, line int) (time.Duration, bool) {
+		ln := strconv.Itoa(line)
+		out, err := exec.Command("git", "-C", dir, "blame", "--porcelain", "-L", ln+","+ln, "--", filepath.FromSlash(path)).Output()
+		if err != nil {
+			return 0, false
+		}
+		for _, l := range strings.Split(string(out), "\n") {
+			if ts, ok := strings.CutPrefix(l, "author-time "); ok {
+				secs, err := strconv.ParseInt(ts, 10, 64)
+				if err != nil {
+					return 0, false
+				}
+				return time.Since(time.Unix(secs, 0)), true
+			}
+		}
+		return 0, false
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnote
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	md "rsc.io/markdown"
+)
+
+// gobBlockTypes and gobInlineTypes list every concrete md.Block and
+// md.Inline implementation the parser returned by NewParser can
+// produce. A cached *md.Document can hold any of them inside an
+// interface value (in Blocks, in a Heading or Paragraph's Text, or
+// nested inside a List, Item or Quote), and gob can only encode an
+// interface value whose concrete type has been registered — so every
+// entry here must stay in sync with what rsc.io/markdown emits, not
+// just the ones exercised by any particular test fragment.
+var (
+	gobBlockTypes = []any{
+		&md.Heading{}, &md.Text{}, &md.Paragraph{}, &md.List{}, &md.Item{},
+		&md.CodeBlock{}, &md.HTMLBlock{}, &md.Quote{}, &md.Empty{}, &md.ThematicBreak{},
+	}
+	gobInlineTypes = []any{
+		&md.Plain{}, &md.Link{}, &md.Code{}, &md.Strong{}, &md.Emph{},
+		&md.Del{}, &md.Image{}, &md.HardBreak{}, &md.SoftBreak{},
+	}
+)
+
+func init() {
+	for _, v := range gobBlockTypes {
+		gob.Register(v)
+	}
+	for _, v := range gobInlineTypes {
+		gob.Register(v)
+	}
+}
+
+// An Index is a persistent, on-disk cache of parsed fragment files,
+// keyed by file path and content hash. It lets repeated calls to Merge
+// over a large, mostly-unchanged tree of fragments skip reparsing files
+// that haven't changed since the index was last saved.
+//
+// This mirrors, at a much smaller scale, the spots/FileRuns/PakRuns
+// invalidation scheme godoc's index.go uses for full-text indexing:
+// cheap per-file fingerprints decide what can be reused, and only the
+// changed inputs do real work.
+type Index struct {
+	fsys      fs.FS
+	cachePath string
+	entries   map[string]indexEntry // filename -> cached parse
+}
+
+// indexEntry is the cached, gob-encodable parse of a single fragment file.
+type indexEntry struct {
+	Hash [sha256.Size]byte
+	Doc  *md.Document
+}
+
+// NewIndex returns an Index backed by the fragment tree fsys, persisting
+// its cache to cachePath. If cachePath already exists, its contents are
+// loaded as the initial cache; otherwise Index starts with an empty
+// cache and creates cachePath on the first call to Merge.
+func NewIndex(fsys fs.FS, cachePath string) (*Index, error) {
+	idx := &Index{fsys: fsys, cachePath: cachePath, entries: map[string]indexEntry{}}
+	f, err := os.Open(cachePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err := gob.NewDecoder(f).Decode(&idx.entries); err != nil {
+		return nil, fmt.Errorf("relnote: decoding index %s: %w", cachePath, err)
+	}
+	return idx, nil
+}
+
+// Merge is like the package-level Merge function, but reparses only the
+// files whose content has changed since the index was built or last
+// saved, reusing cached parses (and their link maps) for the rest. It
+// writes the updated cache to the Index's cachePath before returning.
+func (idx *Index) Merge() (*md.Document, error) {
+	filenames, err := sortedMarkdownFilenames(idx.fsys)
+	if err != nil {
+		return nil, err
+	}
+	fresh := make(map[string]indexEntry, len(filenames))
+	doc, err := stitch(filenames, func(filename string) (*md.Document, error) {
+		hash, err := hashFile(idx.fsys, filename)
+		if err != nil {
+			return nil, err
+		}
+		if e, ok := idx.entries[filename]; ok && e.Hash == hash {
+			fresh[filename] = e
+			return cloneTopLevel(e.Doc), nil
+		}
+		newdoc, err := parseFile(idx.fsys, filename)
+		if err != nil {
+			return nil, err
+		}
+		fresh[filename] = indexEntry{Hash: hash, Doc: newdoc}
+		return cloneTopLevel(newdoc), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	idx.entries = fresh
+	if err := idx.save(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (idx *Index) save() error {
+	f, err := os.Create(idx.cachePath)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(idx.entries); err != nil {
+		f.Close()
+		return fmt.Errorf("relnote: encoding index %s: %w", idx.cachePath, err)
+	}
+	return f.Close()
+}
+
+func hashFile(fsys fs.FS, filename string) ([sha256.Size]byte, error) {
+	f, err := fsys.Open(filename)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// cloneTopLevel returns a document with the same links and a copy of
+// doc's top-level blocks (each block itself shallow-copied). stitch
+// adjusts the line positions of a file's top-level blocks in place to
+// fit the merged document; cloning keeps that from corrupting the
+// cached copy, so the cache stays valid across repeated Merge calls.
+func cloneTopLevel(doc *md.Document) *md.Document {
+	blocks := make([]md.Block, len(doc.Blocks))
+	for i, b := range doc.Blocks {
+		blocks[i] = cloneBlock(b)
+	}
+	return &md.Document{Blocks: blocks, Links: doc.Links}
+}
+
+func cloneBlock(b md.Block) md.Block {
+	switch b := b.(type) {
+	case *md.Heading:
+		c := *b
+		return &c
+	case *md.Text:
+		c := *b
+		return &c
+	case *md.Paragraph:
+		c := *b
+		return &c
+	case *md.List:
+		c := *b
+		return &c
+	case *md.Item:
+		c := *b
+		return &c
+	case *md.CodeBlock:
+		c := *b
+		return &c
+	case *md.HTMLBlock:
+		c := *b
+		return &c
+	case *md.Quote:
+		c := *b
+		return &c
+	case *md.Empty:
+		c := *b
+		return &c
+	default:
+		panic(fmt.Sprintf("unknown block type %T", b))
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnote
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIndexMergeInlineFormatting(t *testing.T) {
+	fsys := fstest.MapFS{
+		"tools.md": {Data: []byte("## Tools\n\nThe `net/http.Client` type is now **faster** and _simpler_.\n")},
+	}
+	cache := filepath.Join(t.TempDir(), "index.gob")
+
+	idx, err := NewIndex(fsys, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc1, err := idx.Merge()
+	if err != nil {
+		t.Fatalf("first Merge: %v", err)
+	}
+
+	// Reload the index from disk, as a long-running CI job would
+	// between invocations, and merge again without changing the
+	// source tree: this exercises the gob-decoded cache entries, not
+	// freshly parsed ones.
+	idx2, err := NewIndex(fsys, cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc2, err := idx2.Merge()
+	if err != nil {
+		t.Fatalf("second Merge (from cache): %v", err)
+	}
+
+	if got, want := blocksText(doc2.Blocks), blocksText(doc1.Blocks); got != want {
+		t.Errorf("cached merge produced different text\ngot:  %q\nwant: %q", got, want)
+	}
+}
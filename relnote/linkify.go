@@ -0,0 +1,203 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnote
+
+import (
+	"regexp"
+	"strings"
+
+	md "rsc.io/markdown"
+)
+
+// Linkify rewrites plain-text references to Go symbols in doc's prose
+// (for example "pkg.Func", "(*T).Method" or "pkg.Const") into md.Link
+// inlines. known is called with each candidate symbol reference that
+// is, or can be, qualified with a package (unqualified references
+// outside a package section are left as plain text), and reports the URL to link to and whether the reference is actually a
+// known symbol; a typical known func resolves "pkg.Symbol" to
+// "/pkg/pkg/#Symbol".
+//
+// Within a "Minor changes to the library" section, the surrounding
+// package is already known from stdlibPackage, so an unqualified
+// reference such as "Symbol" is qualified with that package before
+// being passed to known.
+func Linkify(doc *md.Document, known func(sym string) (url string, ok bool)) error {
+	var pkg string   // package inferred for the current section, if any
+	var pkgLevel int // level of the heading that set pkg
+	for _, b := range doc.Blocks {
+		if h, ok := b.(*md.Heading); ok {
+			if p := headingStdlibPackage(h); p != "" {
+				pkg, pkgLevel = p, h.Level
+			} else if h.Level <= pkgLevel {
+				// A heading at or above the package heading's level
+				// ends that package's section.
+				pkg = ""
+			}
+			continue
+		}
+		linkifyBlock(b, pkg, known)
+	}
+	return nil
+}
+
+// headingStdlibPackage returns the package name for a heading inserted
+// by Merge for a standard library package (see stdlibPackageHeading),
+// or "" if h is not such a heading.
+func headingStdlibPackage(h *md.Heading) string {
+	if h.Text == nil || len(h.Text.Inline) != 1 {
+		return ""
+	}
+	l, ok := h.Text.Inline[0].(*md.Link)
+	if !ok || len(l.Inner) != 1 {
+		return ""
+	}
+	p, ok := l.Inner[0].(*md.Plain)
+	if !ok || l.URL != "/pkg/"+p.Text+"/" {
+		return ""
+	}
+	return p.Text
+}
+
+func linkifyBlock(b md.Block, pkg string, known func(sym string) (string, bool)) {
+	switch b := b.(type) {
+	case *md.Paragraph:
+		b.Text.Inline = linkifyInlines(b.Text.Inline, pkg, known)
+	case *md.Heading:
+		b.Text.Inline = linkifyInlines(b.Text.Inline, pkg, known)
+	case *md.List:
+		for _, item := range b.Items {
+			for _, ib := range item.(*md.Item).Blocks {
+				linkifyBlock(ib, pkg, known)
+			}
+		}
+	case *md.Quote:
+		for _, qb := range b.Blocks {
+			linkifyBlock(qb, pkg, known)
+		}
+	}
+}
+
+// symRE matches a candidate Go symbol reference:
+//
+//	pkg.Symbol
+//	pkg.Type.Method
+//	(*pkg.Type).Method
+//	Type.Method     (unqualified, resolved against the surrounding package)
+//	(*Type).Method  (unqualified, resolved against the surrounding package)
+//	Symbol          (unqualified, resolved against the surrounding package)
+//
+// The parenthesized-receiver alternative is tried first so that, say,
+// "(*Client).Close" is matched whole rather than as the bare identifier
+// "Client" followed by stray punctuation.
+var symRE = regexp.MustCompile(
+	`\(\*[a-zA-Z][a-zA-Z0-9]*(?:\.[A-Z][a-zA-Z0-9]*)?\)\.[A-Z][a-zA-Z0-9]*` +
+		`|[a-zA-Z][a-zA-Z0-9]*(?:\.[A-Z][a-zA-Z0-9]*){1,2}` +
+		`|[A-Z][a-zA-Z0-9]*`)
+
+func linkifyInlines(ins []md.Inline, pkg string, known func(sym string) (string, bool)) []md.Inline {
+	var out []md.Inline
+	for _, in := range ins {
+		p, ok := in.(*md.Plain)
+		if !ok {
+			out = append(out, in)
+			continue
+		}
+		out = append(out, linkifyPlain(p, pkg, known)...)
+	}
+	return out
+}
+
+func linkifyPlain(p *md.Plain, pkg string, known func(sym string) (string, bool)) []md.Inline {
+	var out []md.Inline
+	text := p.Text
+	for {
+		loc := symRE.FindStringIndex(text)
+		if loc == nil {
+			if text != "" {
+				out = append(out, &md.Plain{Text: text})
+			}
+			return out
+		}
+		ref := text[loc[0]:loc[1]]
+		if loc[0] > 0 {
+			out = append(out, &md.Plain{Text: text[:loc[0]]})
+		}
+		if qualified, ok := qualifyRef(ref, pkg); ok {
+			if url, ok := known(qualified); ok {
+				out = append(out, &md.Link{
+					URL:   url,
+					Inner: []md.Inline{&md.Plain{Text: ref}},
+				})
+				text = text[loc[1]:]
+				continue
+			}
+		}
+		out = append(out, &md.Plain{Text: ref})
+		text = text[loc[1]:]
+	}
+}
+
+// qualifyRef turns a matched candidate reference into a fully
+// package-qualified symbol path ("pkg.Symbol" or "pkg.Type.Method")
+// suitable to pass to known. It reports false if ref is unqualified
+// and pkg is empty, since there's then no package to qualify it with.
+func qualifyRef(ref, pkg string) (string, bool) {
+	if inner, method, ok := parenMethod(ref); ok {
+		if i := lastDot(inner); i >= 0 {
+			return inner[:i] + "." + inner[i+1:] + "." + method, true
+		}
+		if pkg == "" {
+			return "", false
+		}
+		return pkg + "." + inner + "." + method, true
+	}
+	switch strings.Count(ref, ".") {
+	case 2:
+		return ref, true // already "pkg.Type.Method"
+	case 1:
+		first, _, _ := strings.Cut(ref, ".")
+		if isLowerIdent(first) {
+			return ref, true // already "pkg.Symbol"
+		}
+		if pkg == "" {
+			return "", false
+		}
+		return pkg + "." + ref, true // unqualified "Type.Method"
+	default:
+		if pkg == "" {
+			return "", false
+		}
+		return pkg + "." + ref, true // unqualified "Symbol"
+	}
+}
+
+// parenMethod splits a matched "(*X).Method" reference (where X may
+// itself be "pkg.Type" or a bare, unqualified "Type") into X and
+// Method. It reports false if ref is not of that form.
+func parenMethod(ref string) (x, method string, ok bool) {
+	if !strings.HasPrefix(ref, "(*") {
+		return "", "", false
+	}
+	i := strings.Index(ref, ").")
+	if i < 0 {
+		return "", "", false
+	}
+	return ref[2:i], ref[i+2:], true
+}
+
+// isLowerIdent reports whether s begins with a lowercase ASCII letter,
+// as a package identifier does.
+func isLowerIdent(s string) bool {
+	return s != "" && s[0] >= 'a' && s[0] <= 'z'
+}
+
+func lastDot(s string) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == '.' {
+			return i
+		}
+	}
+	return -1
+}
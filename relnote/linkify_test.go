@@ -0,0 +1,65 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnote
+
+import (
+	"testing"
+
+	md "rsc.io/markdown"
+)
+
+func TestLinkifyParenMethod(t *testing.T) {
+	// headingStdlibPackage only recognizes the synthetic heading that
+	// Merge inserts (see stdlibPackageHeading), not an ordinary parsed
+	// "#### net/http" heading, so build the document the way Merge would.
+	body := NewParser().Parse("The (*Client).CloseIdleConnections method is new.\n")
+	doc := &md.Document{
+		Blocks: append([]md.Block{stdlibPackageHeading("net/http", 0)}, body.Blocks...),
+		Links:  map[string]*md.Link{},
+	}
+	var got string
+	known := func(sym string) (string, bool) {
+		got = sym
+		return "/pkg/net/http/#Client.CloseIdleConnections", sym == "net/http.Client.CloseIdleConnections"
+	}
+	if err := Linkify(doc, known); err != nil {
+		t.Fatal(err)
+	}
+	if want := "net/http.Client.CloseIdleConnections"; got != want {
+		t.Errorf("known called with %q, want %q", got, want)
+	}
+
+	var found bool
+	walkInlineLinks(doc.Blocks, func(l *md.Link) {
+		if l.URL == "/pkg/net/http/#Client.CloseIdleConnections" {
+			found = true
+		}
+	})
+	if !found {
+		t.Error("no link to /pkg/net/http/#Client.CloseIdleConnections in the linkified document")
+	}
+}
+
+func TestQualifyRef(t *testing.T) {
+	cases := []struct {
+		ref, pkg, want string
+		ok             bool
+	}{
+		{"pkg.Func", "", "pkg.Func", true},
+		{"pkg.Type.Method", "", "pkg.Type.Method", true},
+		{"(*pkg.Type).Method", "", "pkg.Type.Method", true},
+		{"(*Client).Close", "net/http", "net/http.Client.Close", true},
+		{"Type.Method", "net/http", "net/http.Type.Method", true},
+		{"Symbol", "net/http", "net/http.Symbol", true},
+		{"Symbol", "", "", false},
+		{"(*Client).Close", "", "", false},
+	}
+	for _, c := range cases {
+		got, ok := qualifyRef(c.ref, c.pkg)
+		if got != c.want || ok != c.ok {
+			t.Errorf("qualifyRef(%q, %q) = %q, %v; want %q, %v", c.ref, c.pkg, got, ok, c.want, c.ok)
+		}
+	}
+}
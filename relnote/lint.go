@@ -0,0 +1,190 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnote
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"time"
+
+	md "rsc.io/markdown"
+)
+
+// FragmentError describes a single problem found in a fragment file.
+type FragmentError struct {
+	Path string      // path of the fragment, relative to the tree root
+	Pos  md.Position // position of the problem within the file
+	Rule string      // short identifier for the rule that was violated
+	Msg  string      // human-readable description of the problem
+}
+
+func (e *FragmentError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s", e.Path, e.Pos.StartLine, e.Pos.EndLine, e.Rule, e.Msg)
+}
+
+// CheckTreeOptions control optional CheckTree rules.
+type CheckTreeOptions struct {
+	// StaleTODOAfter, if positive, flags fragments whose only content is
+	// a TODO that was introduced at least this long ago.
+	StaleTODOAfter time.Duration
+
+	// BlameAge returns how long ago the line at the given position in
+	// path was last changed. It is only called when StaleTODOAfter is
+	// positive. If ok is false, the age is unknown and the fragment is
+	// not flagged as stale.
+	BlameAge func(path string, line int) (age time.Duration, ok bool)
+}
+
+// CheckTree walks fsys and reports all the problems found in the
+// fragments it contains: what CheckFragment reports (except that a
+// leading '+'-prefixed heading is allowed), plus
+// heading level jumps, duplicate '+'-prefixed headings that would
+// collide on merge, fragments whose top-level heading contradicts
+// their inferred package, unreferenced link definitions, and (if
+// opts.StaleTODOAfter is set) fragments whose only content is an
+// old TODO.
+func CheckTree(fsys fs.FS, opts CheckTreeOptions) []FragmentError {
+	var errs []FragmentError
+	filenames, err := sortedMarkdownFilenames(fsys)
+	if err != nil {
+		return []FragmentError{{Path: ".", Rule: "walk", Msg: err.Error()}}
+	}
+	plusHeadings := map[string]string{} // heading text -> first file that used it
+	for _, filename := range filenames {
+		doc, err := parseFile(fsys, filename)
+		if err != nil {
+			errs = append(errs, FragmentError{Path: filename, Rule: "parse", Msg: err.Error()})
+			continue
+		}
+		errs = append(errs, checkFragmentTree(filename, doc, plusHeadings, opts)...)
+	}
+	return errs
+}
+
+func checkFragmentTree(filename string, doc *md.Document, plusHeadings map[string]string, opts CheckTreeOptions) []FragmentError {
+	var errs []FragmentError
+	report := func(pos md.Position, rule, format string, args ...any) {
+		errs = append(errs, FragmentError{Path: filename, Pos: pos, Rule: rule, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if len(doc.Blocks) == 0 {
+		report(md.Position{}, "empty", "empty content")
+		return errs
+	}
+	first, ok := doc.Blocks[0].(*md.Heading)
+	if !ok {
+		report(doc.Blocks[0].Pos(), "no-heading", "does not start with a heading")
+		return errs
+	}
+	htext := text(first)
+	// Unlike CheckFragment, a leading '+'-prefixed heading is not an
+	// error here; it is only checked for collisions with other files.
+	switch {
+	case strings.TrimSpace(htext) == "":
+		report(first.Pos(), "empty-heading", "starts with an empty heading")
+	case !headingTextMustMatch(htext):
+		// A '+'-prefixed heading is inserted verbatim on merge, so two
+		// fragments using the same one would collide.
+		if prev, dup := plusHeadings[htext]; dup {
+			report(first.Pos(), "dup-heading", "heading %q also used in %s; the two will collide on merge", htext, prev)
+		} else {
+			plusHeadings[htext] = filename
+		}
+	default:
+		if pkg := stdlibPackage(filename); pkg != "" && !strings.Contains(htext, pkg) {
+			report(first.Pos(), "pkg-mismatch", "heading %q does not mention inferred package %q", htext, pkg)
+		}
+	}
+
+	// Heading-content and heading-level-jump checks.
+	levels := []int{first.Level}
+	cur := first
+	found := false
+	var todos []md.Block // blocks containing a TODO
+	onlyTODO := true     // no content other than TODOs seen
+	for _, b := range doc.Blocks[1:] {
+		if h, ok := b.(*md.Heading); ok {
+			if len(levels) > 0 && h.Level > levels[len(levels)-1]+1 {
+				report(h.Pos(), "heading-level-jump", "heading level %d follows level %d with nothing in between", h.Level, levels[len(levels)-1])
+			}
+			levels = append(levels, h.Level)
+			if !found {
+				report(cur.Pos(), "missing-content", "section with heading %q needs a TODO or a sentence", text(cur))
+			}
+			cur = h
+			found = false
+			continue
+		}
+		t := text(b)
+		if strings.Contains(t, "TODO") {
+			found = true
+			todos = append(todos, b)
+		} else {
+			if strings.TrimSpace(t) != "" {
+				onlyTODO = false
+			}
+			if strings.ContainsAny(t, ".?!") {
+				found = true
+			}
+		}
+	}
+	if !found {
+		report(cur.Pos(), "missing-content", "section with heading %q needs a TODO or a sentence", text(cur))
+	}
+	if onlyTODO && opts.StaleTODOAfter > 0 && opts.BlameAge != nil {
+		for _, b := range todos {
+			if age, ok := opts.BlameAge(filename, b.Pos().StartLine); ok && age >= opts.StaleTODOAfter {
+				report(b.Pos(), "stale-todo", "TODO has been unresolved for %s", age.Round(time.Hour))
+			}
+		}
+	}
+
+	// Unreferenced link definitions: a reference-style link resolves to
+	// the URL of the definition it names, so a definition whose URL is
+	// never reused by an inline link is presumably unreferenced.
+	usedURLs := map[string]bool{}
+	walkInlineLinks(doc.Blocks, func(l *md.Link) {
+		usedURLs[l.URL] = true
+	})
+	for key, link := range doc.Links {
+		if !usedURLs[link.URL] {
+			report(md.Position{}, "unused-link", "link definition %q is never referenced", key)
+		}
+	}
+
+	return errs
+}
+
+// walkInlineLinks calls f for every *md.Link inline found in bs.
+func walkInlineLinks(bs []md.Block, f func(*md.Link)) {
+	var walkInlines func([]md.Inline)
+	walkInlines = func(ins []md.Inline) {
+		for _, in := range ins {
+			if l, ok := in.(*md.Link); ok {
+				f(l)
+				walkInlines(l.Inner)
+			}
+		}
+	}
+	for _, b := range bs {
+		switch b := b.(type) {
+		case *md.Heading:
+			if b.Text != nil {
+				walkInlines(b.Text.Inline)
+			}
+		case *md.Paragraph:
+			if b.Text != nil {
+				walkInlines(b.Text.Inline)
+			}
+		case *md.List:
+			for _, item := range b.Items {
+				walkInlineLinks(item.(*md.Item).Blocks, f)
+			}
+		case *md.Quote:
+			walkInlineLinks(b.Blocks, f)
+		}
+	}
+}
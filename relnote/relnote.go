@@ -142,6 +142,13 @@ func inlineText(ins []md.Inline) string {
 	return buf.String()
 }
 
+// MergeOptions control optional post-processing performed by Merge.
+type MergeOptions struct {
+	// Linkify, if non-nil, is used to linkify API symbol references in
+	// the merged document; see the Linkify function.
+	Linkify func(sym string) (url string, ok bool)
+}
+
 // Merge combines the markdown documents (files ending in ".md") in the tree rooted
 // at fs into a single document.
 // The blocks of the documents are concatenated in lexicographic order by filename.
@@ -151,29 +158,60 @@ func inlineText(ins []md.Inline) string {
 // Files in the "minor changes" directory are named after the package to which they refer,
 // and will have the package heading inserted automatically.
 func Merge(fsys fs.FS) (*md.Document, error) {
+	return MergeOpts(fsys, MergeOptions{})
+}
+
+// MergeOpts is like Merge, but allows the caller to request optional
+// post-processing via opts.
+func MergeOpts(fsys fs.FS, opts MergeOptions) (*md.Document, error) {
 	filenames, err := sortedMarkdownFilenames(fsys)
 	if err != nil {
 		return nil, err
 	}
+	doc, err := stitch(filenames, func(filename string) (*md.Document, error) {
+		return parseFile(fsys, filename)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Linkify != nil {
+		if err := Linkify(doc, opts.Linkify); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// stitch combines the already-parsed documents named by filenames (fetched
+// lazily via getDoc, in order) into a single document, the way Merge does.
+// It is shared by Merge and Index.Merge, the latter supplying getDoc from
+// a cache instead of reparsing every file.
+func stitch(filenames []string, getDoc func(filename string) (*md.Document, error)) (*md.Document, error) {
 	doc := &md.Document{Links: map[string]*md.Link{}}
 	var prevPkg string // previous stdlib package, if any
 	for _, filename := range filenames {
-		newdoc, err := parseFile(fsys, filename)
+		newdoc, err := getDoc(filename)
 		if err != nil {
 			return nil, err
 		}
 		if len(newdoc.Blocks) == 0 {
 			continue
 		}
-		if len(doc.Blocks) > 0 {
-			// If this is the first file of a new stdlib package under the "Minor changes
-			// to the library" section, insert a heading for the package.
-			pkg := stdlibPackage(filename)
-			if pkg != "" && pkg != prevPkg {
-				h := stdlibPackageHeading(pkg, lastBlock(doc).Pos().EndLine)
-				doc.Blocks = append(doc.Blocks, h)
+		// If this is the first file of a new stdlib package under the "Minor changes
+		// to the library" section, insert a heading for the package — even if it's
+		// the very first file in the whole tree, so a stdlib-package section never
+		// starts with bare, unheaded prose.
+		pkg := stdlibPackage(filename)
+		if pkg != "" && pkg != prevPkg {
+			var lastLine int
+			if len(doc.Blocks) > 0 {
+				lastLine = lastBlock(doc).Pos().EndLine
 			}
-			prevPkg = pkg
+			h := stdlibPackageHeading(pkg, lastLine)
+			doc.Blocks = append(doc.Blocks, h)
+		}
+		prevPkg = pkg
+		if len(doc.Blocks) > 0 {
 			// Put a blank line between the current and new blocks, so that the end
 			// of a file acts as a blank line.
 			lastLine := lastBlock(doc).Pos().EndLine
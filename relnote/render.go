@@ -0,0 +1,302 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnote
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	md "rsc.io/markdown"
+)
+
+// RenderOptions control how a merged document is rendered.
+type RenderOptions struct {
+	// BaseURL, if non-empty, is prepended to the synthetic "/pkg/PKG/"
+	// links produced for standard library package headings, and to any
+	// other root-relative links in the document. It should not end in
+	// a slash.
+	BaseURL string
+}
+
+// RenderHTML renders doc as a single, self-contained HTML page.
+//
+// Heading IDs are computed by assignHeadingIDs: an explicit ID from doc
+// where the source gives one, otherwise a slug of the heading text, so
+// links into the page and the generated table of contents are stable
+// across merges. Links of the
+// form "/pkg/PKG/" (as produced for the headings that Merge inserts
+// for standard library packages) are rewritten using opts.BaseURL.
+func RenderHTML(doc *md.Document, opts RenderOptions) ([]byte, error) {
+	var toc bytes.Buffer
+	var body bytes.Buffer
+	r := &htmlRenderer{opts: opts, ids: assignHeadingIDs(doc.Blocks)}
+	for _, b := range doc.Blocks {
+		if h, ok := b.(*md.Heading); ok {
+			fmt.Fprintf(&toc, "%s<li><a href=\"#%s\">%s</a></li>\n",
+				strings.Repeat("  ", maxInt(0, h.Level-1)), r.headingID(h), html.EscapeString(text(h)))
+		}
+		if err := r.block(&body, b); err != nil {
+			return nil, err
+		}
+	}
+	var out bytes.Buffer
+	fmt.Fprint(&out, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n</head>\n<body>\n")
+	fmt.Fprint(&out, "<nav id=\"toc\">\n<ul>\n")
+	out.Write(toc.Bytes())
+	fmt.Fprint(&out, "</ul>\n</nav>\n")
+	out.Write(body.Bytes())
+	fmt.Fprint(&out, "</body>\n</html>\n")
+	return out.Bytes(), nil
+}
+
+// htmlRenderer holds the state needed to render a document to HTML.
+type htmlRenderer struct {
+	opts RenderOptions
+	ids  map[*md.Heading]string // heading IDs, assigned by assignHeadingIDs
+}
+
+func (r *htmlRenderer) block(w *bytes.Buffer, b md.Block) error {
+	switch b := b.(type) {
+	case *md.Heading:
+		tag := "h" + strconv.Itoa(b.Level)
+		fmt.Fprintf(w, "<%s id=\"%s\">", tag, r.headingID(b))
+		r.inlines(w, inlinesOf(b.Text))
+		fmt.Fprintf(w, "</%s>\n", tag)
+	case *md.Paragraph:
+		fmt.Fprint(w, "<p>")
+		r.inlines(w, inlinesOf(b.Text))
+		fmt.Fprint(w, "</p>\n")
+	case *md.Text:
+		// A tight list item's content: a *md.Text block rather than a
+		// *md.Paragraph. Render it the same way.
+		fmt.Fprint(w, "<p>")
+		r.inlines(w, b.Inline)
+		fmt.Fprint(w, "</p>\n")
+	case *md.List:
+		tag := "ul"
+		if b.Start != 0 {
+			tag = "ol"
+		}
+		fmt.Fprintf(w, "<%s>\n", tag)
+		for _, item := range b.Items {
+			fmt.Fprint(w, "<li>")
+			for _, ib := range item.(*md.Item).Blocks {
+				if err := r.block(w, ib); err != nil {
+					return err
+				}
+			}
+			fmt.Fprint(w, "</li>\n")
+		}
+		fmt.Fprintf(w, "</%s>\n", tag)
+	case *md.CodeBlock:
+		fmt.Fprint(w, "<pre><code>")
+		for _, line := range b.Text {
+			fmt.Fprintln(w, html.EscapeString(line))
+		}
+		fmt.Fprint(w, "</code></pre>\n")
+	case *md.HTMLBlock:
+		for _, line := range b.Text {
+			fmt.Fprintln(w, line)
+		}
+	case *md.Quote:
+		fmt.Fprint(w, "<blockquote>\n")
+		for _, qb := range b.Blocks {
+			if err := r.block(w, qb); err != nil {
+				return err
+			}
+		}
+		fmt.Fprint(w, "</blockquote>\n")
+	case *md.Empty:
+		// Nothing to render.
+	default:
+		return fmt.Errorf("relnote: RenderHTML: unsupported block type %T", b)
+	}
+	return nil
+}
+
+func (r *htmlRenderer) inlines(w *bytes.Buffer, ins []md.Inline) {
+	for _, in := range ins {
+		switch in := in.(type) {
+		case *md.Link:
+			fmt.Fprintf(w, "<a href=\"%s\">", html.EscapeString(r.rewriteURL(in.URL)))
+			r.inlines(w, in.Inner)
+			fmt.Fprint(w, "</a>")
+		default:
+			// Other inline kinds (plain text, emphasis, code spans, ...)
+			// don't need link rewriting, so fall back to their plain text.
+			fmt.Fprint(w, html.EscapeString(inlineText([]md.Inline{in})))
+		}
+	}
+}
+
+// rewriteURL rewrites root-relative URLs, such as the "/pkg/PKG/" links
+// produced for stdlib package headings, using opts.BaseURL.
+func (r *htmlRenderer) rewriteURL(url string) string {
+	if r.opts.BaseURL == "" || !strings.HasPrefix(url, "/") {
+		return url
+	}
+	return r.opts.BaseURL + url
+}
+
+// headingID returns the ID assigned to h by assignHeadingIDs.
+func (r *htmlRenderer) headingID(h *md.Heading) string {
+	return r.ids[h]
+}
+
+// assignHeadingIDs computes a stable, unique ID for every heading in
+// blocks, for use as an HTML anchor and TOC link target.
+//
+// The rsc.io/markdown parser's HeadingIDs option (enabled by NewParser)
+// only populates Heading.ID from an explicit "{#id}" suffix in the
+// source; fragments and the synthetic stdlibPackageHeading never write
+// one, so in practice ID is always empty. Fall back to a slug of the
+// heading text in that case, disambiguating duplicates by appending
+// "-1", "-2", and so on.
+func assignHeadingIDs(blocks []md.Block) map[*md.Heading]string {
+	ids := map[*md.Heading]string{}
+	seen := map[string]int{}
+	var walk func([]md.Block)
+	walk = func(bs []md.Block) {
+		for _, b := range bs {
+			switch b := b.(type) {
+			case *md.Heading:
+				id := b.ID
+				if id == "" {
+					id = slugify(text(b))
+				}
+				if id == "" {
+					id = "section"
+				}
+				if n, dup := seen[id]; dup {
+					n++
+					seen[id] = n
+					id = fmt.Sprintf("%s-%d", id, n)
+				} else {
+					seen[id] = 0
+				}
+				ids[b] = id
+			case *md.List:
+				for _, item := range b.Items {
+					walk(item.(*md.Item).Blocks)
+				}
+			case *md.Quote:
+				walk(b.Blocks)
+			}
+		}
+	}
+	walk(blocks)
+	return ids
+}
+
+// inlinesOf returns the inline content of a text block, or nil if t is nil.
+func inlinesOf(t *md.Text) []md.Inline {
+	if t == nil {
+		return nil
+	}
+	return t.Inline
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// RenderText renders doc as fixed-width plain text, suitable for a
+// CHANGELOG-style distribution. Headings are rendered as underlined
+// lines, list items as "-"-prefixed lines, and links as "text (URL)";
+// a link inside a heading keeps only its text.
+func RenderText(doc *md.Document, opts RenderOptions) ([]byte, error) {
+	var out bytes.Buffer
+	for _, b := range doc.Blocks {
+		if err := renderTextBlock(&out, b, opts, 0); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+func renderTextBlock(w *bytes.Buffer, b md.Block, opts RenderOptions, indent int) error {
+	pad := strings.Repeat("  ", indent)
+	switch b := b.(type) {
+	case *md.Heading:
+		t := text(b)
+		fmt.Fprintln(w, pad+t)
+		underline := "="
+		if b.Level > 1 {
+			underline = "-"
+		}
+		fmt.Fprintln(w, pad+strings.Repeat(underline, len(t)))
+		fmt.Fprintln(w)
+	case *md.Paragraph:
+		fmt.Fprintln(w, pad+textWithLinks(b.Text, opts))
+		fmt.Fprintln(w)
+	case *md.Text:
+		// A tight list item's content: a *md.Text block rather than a
+		// *md.Paragraph. Render it the same way.
+		fmt.Fprintln(w, pad+textWithLinks(b, opts))
+		fmt.Fprintln(w)
+	case *md.List:
+		for _, item := range b.Items {
+			it := item.(*md.Item)
+			for i, ib := range it.Blocks {
+				prefix := "  "
+				if i == 0 {
+					prefix = "- "
+				}
+				fmt.Fprint(w, pad+prefix)
+				if err := renderTextBlock(w, ib, opts, 0); err != nil {
+					return err
+				}
+			}
+		}
+	case *md.CodeBlock:
+		for _, line := range b.Text {
+			fmt.Fprintln(w, pad+"    "+line)
+		}
+		fmt.Fprintln(w)
+	case *md.HTMLBlock, *md.Empty:
+		// Not meaningful as plain text; skip.
+	case *md.Quote:
+		for _, qb := range b.Blocks {
+			if err := renderTextBlock(w, qb, opts, indent+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("relnote: RenderText: unsupported block type %T", b)
+	}
+	return nil
+}
+
+// textWithLinks is like text, but renders links as "text (URL)".
+func textWithLinks(t *md.Text, opts RenderOptions) string {
+	if t == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	for _, in := range t.Inline {
+		if l, ok := in.(*md.Link); ok {
+			buf.WriteString(inlineText(l.Inner))
+			buf.WriteString(" (")
+			buf.WriteString(rewriteTextURL(l.URL, opts))
+			buf.WriteString(")")
+			continue
+		}
+		in.PrintText(&buf)
+	}
+	return buf.String()
+}
+
+func rewriteTextURL(url string, opts RenderOptions) string {
+	if opts.BaseURL == "" || !strings.HasPrefix(url, "/") {
+		return url
+	}
+	return opts.BaseURL + url
+}
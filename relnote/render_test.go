@@ -0,0 +1,49 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnote
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderHTMLTightList(t *testing.T) {
+	doc := NewParser().Parse("## pkg\n\n- item one\n- item two\n")
+	out, err := RenderHTML(doc, RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"item one", "item two", `id="pkg"`, `href="#pkg"`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderTextTightList(t *testing.T) {
+	doc := NewParser().Parse("## pkg\n\n- item one\n- item two\n")
+	out, err := RenderText(doc, RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"item one", "item two"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderHTMLHeadingIDsDedup(t *testing.T) {
+	doc := NewParser().Parse("## pkg\n\nFirst.\n\n## pkg\n\nSecond.\n")
+	out, err := RenderHTML(doc, RenderOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`id="pkg"`, `id="pkg-1"`, `href="#pkg"`, `href="#pkg-1"`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
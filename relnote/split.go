@@ -0,0 +1,249 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnote
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	md "rsc.io/markdown"
+)
+
+// SplitLayout configures the fragment tree produced by Split.
+type SplitLayout struct {
+	// StdlibMinorDir is the directory under which standard-library
+	// "minor changes" fragments are placed, one sub-directory per
+	// package, matching the "*stdlib/*minor/PKG/*.md" convention that
+	// stdlibPackage and Merge already understand.
+	StdlibMinorDir string
+
+	// OtherDir is the directory that non-stdlib top-level sections are
+	// placed into, one file per heading.
+	OtherDir string
+}
+
+// Split is the inverse of Merge: given a single, merged release-notes
+// document, it produces a tree of fragment files that Merge would
+// recombine into (modulo formatting) the same document.
+//
+// Split recognizes the package headings that Merge inserts for
+// standard-library "minor changes" sections (see stdlibPackageHeading),
+// strips them back out, and places the blocks that followed each one
+// under StdlibMinorDir/PKG/fragment.md. Every other top-level heading
+// (and the blocks under it) is written to OtherDir/<slug>.md, with the
+// heading itself kept as the file's leading heading, since Merge
+// requires every other fragment to begin with one.
+//
+// Link definitions reachable from a section's blocks are copied into
+// that fragment's own link map, so each output file is self-contained.
+//
+// This is meant to bootstrap a fragment tree from an existing,
+// hand-written release notes document; it does not try to recover the
+// original fragment boundaries that a Merge may have collapsed (for
+// example, several minor-change fragments for the same package merge
+// into one section, and Split writes that section back out as a
+// single fragment).
+func Split(doc *md.Document, layout SplitLayout) (map[string][]byte, error) {
+	sections, err := splitSections(doc, layout)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string][]byte{}
+	for _, s := range sections {
+		if _, dup := out[s.filename]; dup {
+			return nil, fmt.Errorf("relnote: Split: two sections both map to %s", s.filename)
+		}
+		out[s.filename] = renderFragment(s.blocks, doc.Links)
+	}
+	return out, nil
+}
+
+// section is the blocks destined for one output fragment file.
+type section struct {
+	filename string
+	blocks   []md.Block
+}
+
+func splitSections(doc *md.Document, layout SplitLayout) ([]section, error) {
+	minLevel := minHeadingLevel(doc.Blocks)
+
+	var sections []section
+	var cur *section
+	flush := func() {
+		if cur != nil && len(cur.blocks) > 0 {
+			sections = append(sections, *cur)
+		}
+		cur = nil
+	}
+
+	for _, b := range doc.Blocks {
+		if _, ok := b.(*md.Empty); ok {
+			continue
+		}
+		if h, ok := b.(*md.Heading); ok {
+			if pkg := headingStdlibPackage(h); pkg != "" {
+				flush()
+				cur = &section{filename: path.Join(layout.StdlibMinorDir, pkg, "fragment.md")}
+				continue // the heading itself is synthetic; don't keep it
+			}
+			if cur == nil || h.Level <= minLevel {
+				flush()
+				slug := slugify(text(h))
+				if slug == "" {
+					slug = "section"
+				}
+				cur = &section{filename: path.Join(layout.OtherDir, slug+".md")}
+				cur.blocks = append(cur.blocks, b)
+				continue
+			}
+		}
+		if cur == nil {
+			// Content before any recognized heading; there's nowhere to
+			// put it, so it's dropped rather than guessed at.
+			continue
+		}
+		cur.blocks = append(cur.blocks, b)
+	}
+	flush()
+	return sections, nil
+}
+
+// minHeadingLevel returns the lowest heading level among doc's blocks,
+// other than the synthetic stdlib package headings, or 1 if there are
+// none.
+func minHeadingLevel(bs []md.Block) int {
+	lvl := 0
+	for _, b := range bs {
+		h, ok := b.(*md.Heading)
+		if !ok || headingStdlibPackage(h) != "" {
+			continue
+		}
+		if lvl == 0 || h.Level < lvl {
+			lvl = h.Level
+		}
+	}
+	if lvl == 0 {
+		lvl = 1
+	}
+	return lvl
+}
+
+var nonSlugRE = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns heading text into a lowercase, hyphen-separated file
+// name stem.
+func slugify(s string) string {
+	s = nonSlugRE.ReplaceAllString(strings.ToLower(s), "-")
+	return strings.Trim(s, "-")
+}
+
+// renderFragment renders blocks as Markdown and appends definitions for
+// any links that the blocks reference.
+func renderFragment(blocks []md.Block, links map[string]*md.Link) []byte {
+	var buf bytes.Buffer
+	prevBlank := true
+	for _, b := range blocks {
+		if !prevBlank {
+			buf.WriteByte('\n')
+		}
+		writeBlockMarkdown(&buf, b)
+		prevBlank = false
+	}
+
+	// A reference-style link doesn't retain the key it was defined
+	// under once parsed, only the URL it resolved to, so matching by
+	// URL is the best we can do here; two distinct keys sharing a URL
+	// both get copied into a fragment that uses either one.
+	used := map[string]bool{}
+	walkInlineLinks(blocks, func(l *md.Link) {
+		for key, def := range links {
+			if def.URL == l.URL {
+				used[key] = true
+			}
+		}
+	})
+	if len(used) > 0 {
+		buf.WriteByte('\n')
+		for _, key := range sortedKeys(used) {
+			fmt.Fprintf(&buf, "[%s]: %s\n", key, links[key].URL)
+		}
+	}
+	return buf.Bytes()
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func writeBlockMarkdown(buf *bytes.Buffer, b md.Block) {
+	switch b := b.(type) {
+	case *md.Heading:
+		buf.WriteString(strings.Repeat("#", b.Level))
+		buf.WriteByte(' ')
+		writeInlinesMarkdown(buf, inlinesOf(b.Text))
+		buf.WriteString("\n\n")
+	case *md.Paragraph:
+		writeInlinesMarkdown(buf, inlinesOf(b.Text))
+		buf.WriteString("\n\n")
+	case *md.Text:
+		// Tight list items (no blank line between them) hold their
+		// content directly as a *md.Text, not wrapped in a *md.Paragraph.
+		writeInlinesMarkdown(buf, b.Inline)
+		buf.WriteString("\n\n")
+	case *md.List:
+		for _, item := range b.Items {
+			buf.WriteString("- ")
+			for _, ib := range item.(*md.Item).Blocks {
+				writeBlockMarkdown(buf, ib)
+			}
+		}
+	case *md.CodeBlock:
+		buf.WriteString("```\n")
+		for _, line := range b.Text {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+		buf.WriteString("```\n\n")
+	case *md.HTMLBlock:
+		for _, line := range b.Text {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+		buf.WriteByte('\n')
+	case *md.Quote:
+		for _, qb := range b.Blocks {
+			buf.WriteString("> ")
+			writeBlockMarkdown(buf, qb)
+		}
+	case *md.Empty:
+		// Nothing to write.
+	default:
+		panic(fmt.Sprintf("unknown block type %T", b))
+	}
+}
+
+func writeInlinesMarkdown(buf *bytes.Buffer, ins []md.Inline) {
+	for _, in := range ins {
+		switch in := in.(type) {
+		case *md.Link:
+			buf.WriteByte('[')
+			writeInlinesMarkdown(buf, in.Inner)
+			buf.WriteString("](")
+			buf.WriteString(in.URL)
+			buf.WriteByte(')')
+		default:
+			in.PrintText(buf)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package relnote
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitTightList(t *testing.T) {
+	doc := NewParser().Parse("## pkg\n\n- item one\n- item two\n")
+	out, err := Split(doc, SplitLayout{OtherDir: "other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := out["other/pkg.md"]
+	if !ok {
+		t.Fatalf("missing other/pkg.md in %v", mapKeys(out))
+	}
+	for _, want := range []string{"item one", "item two"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("output %q does not contain %q", got, want)
+		}
+	}
+}
+
+func TestMergeSplitMergeRoundTrip(t *testing.T) {
+	fsys := fstest.MapFS{
+		"stdlib/minor/net/http/client.md": {Data: []byte("The [Client] type now does more.\n\n[Client]: /pkg/net/http/#Client\n")},
+		"tools.md":                        {Data: []byte("## Tools\n\nThe go command now does more.\n")},
+	}
+	doc1, err := Merge(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	split, err := Split(doc1, SplitLayout{StdlibMinorDir: "stdlib/minor", OtherDir: "."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys2 := fstest.MapFS{}
+	for name, data := range split {
+		fsys2[name] = &fstest.MapFile{Data: data}
+	}
+
+	doc2, err := Merge(fsys2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := blocksText(doc1.Blocks), blocksText(doc2.Blocks); got != want {
+		t.Errorf("round trip changed text content\nbefore:\n%s\nafter:\n%s", got, want)
+	}
+}
+
+func mapKeys(m map[string][]byte) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}